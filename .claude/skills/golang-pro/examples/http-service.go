@@ -1,5 +1,8 @@
 // Package main demonstrates a production-ready HTTP service structure
-// This is an example file for the golang-pro skill
+// This is the entry point for the golang-pro skill's example package: it
+// builds on the worker pool, rate limiter, and supervisor defined in the
+// sibling files (worker-pool.go, pipeline.go, supervisor.go), all of which
+// are part of this same package.
 package main
 
 import (
@@ -7,10 +10,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -214,6 +223,220 @@ func (h *UserHandler) respondJSON(w http.ResponseWriter, status int, data any) {
 	}
 }
 
+// =============================================================================
+// Hook Handlers (Script Jobs)
+// =============================================================================
+
+// hookResultTTL bounds how long a completed job's result is kept around for
+// GET /hooks/jobs/{id} to poll, so a client that never polls (or polls once
+// and moves on) doesn't keep it alive forever.
+const hookResultTTL = 10 * time.Minute
+
+// hookResult is a Result plus the time it was stored, so the sweeper can
+// evict entries nobody has polled for within hookResultTTL.
+type hookResult struct {
+	result   Result
+	storedAt time.Time
+}
+
+// HookHandler submits ScriptJobs to a WorkerPool for POST /hooks/{path...}
+// and tracks their results so GET /hooks/jobs/{id} can poll for completion.
+// A background sweeper evicts results older than hookResultTTL so a
+// long-running service doesn't accumulate state for jobs nobody ever polls.
+type HookHandler struct {
+	pool   *WorkerPool
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	nextID  int
+	results map[int]hookResult
+
+	stop chan struct{}
+}
+
+// NewHookHandler returns a HookHandler backed by pool, which must already
+// have a ScriptRunner configured via WorkerPool.SetScriptRunner. Call Stop
+// to halt its background sweeper when the handler is no longer needed.
+func NewHookHandler(pool *WorkerPool, logger *slog.Logger) *HookHandler {
+	h := &HookHandler{
+		pool:    pool,
+		logger:  logger,
+		results: make(map[int]hookResult),
+		stop:    make(chan struct{}),
+	}
+	go h.collectResults()
+	go h.sweep()
+	return h
+}
+
+// collectResults drains the pool's results channel into h.results so
+// JobStatus and synchronous SubmitHook calls can look results up by ID.
+func (h *HookHandler) collectResults() {
+	for result := range h.pool.Results() {
+		h.mu.Lock()
+		h.results[result.JobID] = hookResult{result: result, storedAt: time.Now()}
+		h.mu.Unlock()
+	}
+}
+
+// sweep periodically evicts results older than hookResultTTL.
+func (h *HookHandler) sweep() {
+	ticker := time.NewTicker(hookResultTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-hookResultTTL)
+			h.mu.Lock()
+			for id, entry := range h.results {
+				if entry.storedAt.Before(cutoff) {
+					delete(h.results, id)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// Stop halts the background sweeper goroutine.
+func (h *HookHandler) Stop() {
+	close(h.stop)
+}
+
+// SubmitHook builds a ScriptJob from the request path, headers, query, and
+// body and submits it to the pool. By default it blocks until the job
+// completes and returns its result; pass ?async=true to get a job ID back
+// immediately and poll GET /hooks/jobs/{id} instead.
+func (h *HookHandler) SubmitHook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	env := make([]string, 0, len(r.URL.Query())+len(r.Header))
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			env = append(env, fmt.Sprintf("HOOK_QUERY_%s=%s", key, values[0]))
+		}
+	}
+	for key, values := range r.Header {
+		if len(values) > 0 {
+			env = append(env, fmt.Sprintf("HOOK_HEADER_%s=%s", hookEnvName(key), values[0]))
+		}
+	}
+
+	h.mu.Lock()
+	h.nextID++
+	id := h.nextID
+	h.mu.Unlock()
+
+	job := Job{
+		ID:   id,
+		Kind: JobKindScript,
+		Script: &ScriptJob{
+			Path:  r.PathValue("path"),
+			Env:   env,
+			Stdin: body,
+		},
+	}
+
+	if !h.pool.Submit(r.Context(), job) {
+		h.respondError(w, http.StatusServiceUnavailable, "failed to submit hook job")
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]int{"job_id": id})
+		return
+	}
+
+	result, ok := h.awaitResult(r.Context(), id)
+	if !ok {
+		h.respondError(w, http.StatusGatewayTimeout, "hook did not complete before the request was cancelled")
+		return
+	}
+	h.writeResult(w, result)
+}
+
+// awaitResult polls for id's result until it's available or ctx is done.
+func (h *HookHandler) awaitResult(ctx context.Context, id int) (Result, bool) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		h.mu.Lock()
+		entry, ok := h.results[id]
+		h.mu.Unlock()
+		if ok {
+			return entry.result, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return Result{}, false
+		case <-ticker.C:
+		}
+	}
+}
+
+// JobStatus returns the result for an async hook job, or a pending status
+// if it hasn't completed yet.
+func (h *HookHandler) JobStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	h.mu.Lock()
+	entry, ok := h.results[id]
+	h.mu.Unlock()
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"status": "pending"})
+		return
+	}
+
+	h.writeResult(w, entry.result)
+}
+
+func (h *HookHandler) writeResult(w http.ResponseWriter, result Result) {
+	status := http.StatusOK
+	var errMsg string
+	if result.Err != nil {
+		status = http.StatusUnprocessableEntity
+		errMsg = result.Err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"job_id":   result.JobID,
+		"output":   result.Output,
+		"duration": result.Duration.String(),
+		"error":    errMsg,
+	})
+}
+
+func (h *HookHandler) respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// hookEnvName converts an HTTP header name (e.g. "X-Hub-Signature") into the
+// suffix of its HOOK_HEADER_ env var (e.g. "X_HUB_SIGNATURE").
+func hookEnvName(header string) string {
+	return strings.ToUpper(strings.ReplaceAll(header, "-", "_"))
+}
+
 // =============================================================================
 // Middleware
 // =============================================================================
@@ -251,11 +474,528 @@ func RecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// KeyFunc extracts the rate-limit key from a request, e.g. the client IP or
+// an API key header.
+type KeyFunc func(r *http.Request) string
+
+// RemoteAddrKeyFunc keys by the request's remote IP address.
+func RemoteAddrKeyFunc(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// HeaderKeyFunc keys by the given request header, falling back to
+// RemoteAddrKeyFunc when the header is absent (e.g. an unauthenticated
+// request shouldn't bypass rate limiting entirely).
+func HeaderKeyFunc(header string) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(header); v != "" {
+			return v
+		}
+		return RemoteAddrKeyFunc(r)
+	}
+}
+
+// RateLimitMiddleware admits requests through limiter, keyed by keyFunc. On
+// rejection it responds 429 with Retry-After and X-RateLimit-Remaining
+// headers instead of calling next.
+func RateLimitMiddleware(limiter *RateLimiter, keyFunc KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+
+			if !limiter.Allow(key) {
+				retryAfter := int(math.Ceil(1 / limiter.rate))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(limiter.Remaining(key))))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// =============================================================================
+// Metrics
+// =============================================================================
+
+// Counter, Gauge, and Histogram are minimal instrumentation interfaces. They
+// let the HTTP and worker-pool layers record measurements without depending
+// on a specific backend; a production deployment would back these with
+// Prometheus client_golang collectors behind the same interfaces.
+type Counter interface {
+	Inc()
+	Add(delta float64)
+}
+
+type Gauge interface {
+	Set(value float64)
+	Inc()
+	Dec()
+}
+
+type Histogram interface {
+	Observe(value float64)
+}
+
+// MetricsRegistry is the full set of instruments emitted by this service.
+// NewNoopMetricsRegistry satisfies it without recording anything, which is
+// what tests and this example use so they don't depend on a running
+// Prometheus registry.
+type MetricsRegistry interface {
+	HTTPRequestsTotal(method, path string, status int) Counter
+	HTTPRequestDuration(method, path string) Histogram
+	HTTPInFlightRequests() Gauge
+
+	JobsSubmittedTotal() Counter
+	JobsCompletedTotal(result string) Counter
+	JobDuration() Histogram
+	WorkerPoolQueueDepth() Gauge
+	WorkerPoolActiveWorkers() Gauge
+
+	// Render writes the current metrics snapshot in Prometheus text
+	// exposition format, e.g. for GET /metrics. Named Render rather than
+	// WriteTo so it doesn't shadow io.WriterTo's (int64, error) signature.
+	Render(w io.Writer) error
+}
+
+type noopMetric struct{}
+
+func (noopMetric) Inc()            {}
+func (noopMetric) Dec()            {}
+func (noopMetric) Add(float64)     {}
+func (noopMetric) Set(float64)     {}
+func (noopMetric) Observe(float64) {}
+
+type noopMetricsRegistry struct{}
+
+// NewNoopMetricsRegistry returns a MetricsRegistry whose instruments discard
+// every observation. Useful in tests that shouldn't depend on collector
+// state; see NewInMemoryMetricsRegistry for one that actually records and
+// renders metrics.
+func NewNoopMetricsRegistry() MetricsRegistry { return noopMetricsRegistry{} }
+
+func (noopMetricsRegistry) HTTPRequestsTotal(method, path string, status int) Counter {
+	return noopMetric{}
+}
+func (noopMetricsRegistry) HTTPRequestDuration(method, path string) Histogram { return noopMetric{} }
+func (noopMetricsRegistry) HTTPInFlightRequests() Gauge                       { return noopMetric{} }
+func (noopMetricsRegistry) JobsSubmittedTotal() Counter                       { return noopMetric{} }
+func (noopMetricsRegistry) JobsCompletedTotal(result string) Counter          { return noopMetric{} }
+func (noopMetricsRegistry) JobDuration() Histogram                            { return noopMetric{} }
+func (noopMetricsRegistry) WorkerPoolQueueDepth() Gauge                       { return noopMetric{} }
+func (noopMetricsRegistry) WorkerPoolActiveWorkers() Gauge                    { return noopMetric{} }
+func (noopMetricsRegistry) Render(w io.Writer) error                          { return nil }
+
+// =============================================================================
+// In-Memory Metrics Registry
+// =============================================================================
+
+// defaultHistogramBuckets mirrors client_golang's DefBuckets, in seconds.
+var defaultHistogramBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// inMemoryCounter is a thread-safe Counter backed by a float64 behind a mutex.
+type inMemoryCounter struct {
+	mu    sync.Mutex
+	total float64
+}
+
+func (c *inMemoryCounter) Inc() { c.Add(1) }
+
+func (c *inMemoryCounter) Add(delta float64) {
+	c.mu.Lock()
+	c.total += delta
+	c.mu.Unlock()
+}
+
+func (c *inMemoryCounter) snapshot() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// inMemoryGauge is a thread-safe Gauge backed by a float64 behind a mutex.
+type inMemoryGauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (g *inMemoryGauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+func (g *inMemoryGauge) Inc() {
+	g.mu.Lock()
+	g.value++
+	g.mu.Unlock()
+}
+
+func (g *inMemoryGauge) Dec() {
+	g.mu.Lock()
+	g.value--
+	g.mu.Unlock()
+}
+
+func (g *inMemoryGauge) snapshot() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// inMemoryHistogram is a thread-safe Histogram with cumulative buckets,
+// matching Prometheus's histogram exposition shape.
+type inMemoryHistogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newInMemoryHistogram(buckets []float64) *inMemoryHistogram {
+	return &inMemoryHistogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *inMemoryHistogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sum += value
+	h.count++
+	for i, le := range h.buckets {
+		if value <= le {
+			h.counts[i]++
+		}
+	}
+}
+
+func (h *inMemoryHistogram) snapshot() (buckets []float64, counts []uint64, sum float64, count uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts = make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	return h.buckets, counts, h.sum, h.count
+}
+
+// labeledCounter and labeledHistogram pair an instrument with the label
+// values it was created for, so Render can reconstruct its exposition line
+// without re-deriving labels from a map key.
+type labeledCounter struct {
+	labels  []string
+	counter *inMemoryCounter
+}
+
+type labeledHistogram struct {
+	labels []string
+	hist   *inMemoryHistogram
+}
+
+// InMemoryMetricsRegistry is a dependency-free MetricsRegistry: it
+// accumulates counters, gauges, and histograms in memory and renders them
+// in Prometheus text exposition format, the same format client_golang's
+// promhttp.Handler produces. A production deployment would swap this (and
+// the instruments it returns) for client_golang collectors behind the same
+// interfaces; this lets the example demonstrate a working GET /metrics
+// without that dependency.
+type InMemoryMetricsRegistry struct {
+	mu sync.Mutex
+
+	httpRequestsTotal   map[string]*labeledCounter
+	httpRequestDuration map[string]*labeledHistogram
+	httpInFlight        *inMemoryGauge
+
+	jobsSubmittedTotal   *inMemoryCounter
+	jobsCompletedTotal   map[string]*labeledCounter
+	jobDuration          *inMemoryHistogram
+	workerPoolQueueDepth *inMemoryGauge
+	workerPoolActive     *inMemoryGauge
+}
+
+// NewInMemoryMetricsRegistry returns a MetricsRegistry that actually records
+// and renders the metrics it's asked for.
+func NewInMemoryMetricsRegistry() *InMemoryMetricsRegistry {
+	return &InMemoryMetricsRegistry{
+		httpRequestsTotal:    make(map[string]*labeledCounter),
+		httpRequestDuration:  make(map[string]*labeledHistogram),
+		httpInFlight:         &inMemoryGauge{},
+		jobsSubmittedTotal:   &inMemoryCounter{},
+		jobsCompletedTotal:   make(map[string]*labeledCounter),
+		jobDuration:          newInMemoryHistogram(defaultHistogramBuckets),
+		workerPoolQueueDepth: &inMemoryGauge{},
+		workerPoolActive:     &inMemoryGauge{},
+	}
+}
+
+func (r *InMemoryMetricsRegistry) HTTPRequestsTotal(method, path string, status int) Counter {
+	labels := []string{method, path, strconv.Itoa(status)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.httpRequestsTotal[strings.Join(labels, "\x00")]
+	if !ok {
+		entry = &labeledCounter{labels: labels, counter: &inMemoryCounter{}}
+		r.httpRequestsTotal[strings.Join(labels, "\x00")] = entry
+	}
+	return entry.counter
+}
+
+func (r *InMemoryMetricsRegistry) HTTPRequestDuration(method, path string) Histogram {
+	labels := []string{method, path}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.httpRequestDuration[strings.Join(labels, "\x00")]
+	if !ok {
+		entry = &labeledHistogram{labels: labels, hist: newInMemoryHistogram(defaultHistogramBuckets)}
+		r.httpRequestDuration[strings.Join(labels, "\x00")] = entry
+	}
+	return entry.hist
+}
+
+func (r *InMemoryMetricsRegistry) HTTPInFlightRequests() Gauge { return r.httpInFlight }
+
+func (r *InMemoryMetricsRegistry) JobsSubmittedTotal() Counter { return r.jobsSubmittedTotal }
+
+func (r *InMemoryMetricsRegistry) JobsCompletedTotal(result string) Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.jobsCompletedTotal[result]
+	if !ok {
+		entry = &labeledCounter{labels: []string{result}, counter: &inMemoryCounter{}}
+		r.jobsCompletedTotal[result] = entry
+	}
+	return entry.counter
+}
+
+func (r *InMemoryMetricsRegistry) JobDuration() Histogram         { return r.jobDuration }
+func (r *InMemoryMetricsRegistry) WorkerPoolQueueDepth() Gauge    { return r.workerPoolQueueDepth }
+func (r *InMemoryMetricsRegistry) WorkerPoolActiveWorkers() Gauge { return r.workerPoolActive }
+
+// Render writes every accumulated metric in Prometheus text exposition
+// format.
+func (r *InMemoryMetricsRegistry) Render(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := renderCounters(w, "http_requests_total", "Total HTTP requests by method, path, and status.",
+		[]string{"method", "path", "status"}, r.httpRequestsTotal); err != nil {
+		return err
+	}
+	if err := renderHistograms(w, "http_request_duration_seconds", "HTTP request latency in seconds.",
+		[]string{"method", "path"}, r.httpRequestDuration); err != nil {
+		return err
+	}
+	if err := renderGauge(w, "http_in_flight_requests", "Requests currently being served.", r.httpInFlight); err != nil {
+		return err
+	}
+	if err := renderCounter(w, "jobs_submitted_total", "Total jobs submitted to the worker pool.", r.jobsSubmittedTotal); err != nil {
+		return err
+	}
+	if err := renderCounters(w, "jobs_completed_total", "Total jobs completed by the worker pool, by result.",
+		[]string{"result"}, r.jobsCompletedTotal); err != nil {
+		return err
+	}
+	if err := renderHistogram(w, "job_duration_seconds", "Job processing time in seconds.", nil, r.jobDuration); err != nil {
+		return err
+	}
+	if err := renderGauge(w, "worker_pool_queue_depth", "Jobs currently queued for a worker.", r.workerPoolQueueDepth); err != nil {
+		return err
+	}
+	if err := renderGauge(w, "worker_pool_active_workers", "Workers currently processing a job.", r.workerPoolActive); err != nil {
+		return err
+	}
+	return nil
+}
+
+func renderCounter(w io.Writer, name, help string, c *inMemoryCounter) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n",
+		name, help, name, name, formatFloat(c.snapshot()))
+	return err
+}
+
+func renderCounters(w io.Writer, name, help string, labelNames []string, entries map[string]*labeledCounter) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, labelString(labelNames, entry.labels), formatFloat(entry.counter.snapshot())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderGauge(w io.Writer, name, help string, g *inMemoryGauge) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n",
+		name, help, name, name, formatFloat(g.snapshot()))
+	return err
+}
+
+func renderHistogram(w io.Writer, name, help string, labelNames []string, h *inMemoryHistogram) error {
+	return renderHistogramEntry(w, name, help, labelNames, nil, h)
+}
+
+func renderHistograms(w io.Writer, name, help string, labelNames []string, entries map[string]*labeledHistogram) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := renderHistogramBody(w, name, labelNames, entry.labels, entry.hist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderHistogramEntry(w io.Writer, name, help string, labelNames, labelValues []string, h *inMemoryHistogram) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name); err != nil {
+		return err
+	}
+	return renderHistogramBody(w, name, labelNames, labelValues, h)
+}
+
+func renderHistogramBody(w io.Writer, name string, labelNames, labelValues []string, h *inMemoryHistogram) error {
+	buckets, counts, sum, count := h.snapshot()
+
+	var cumulative uint64
+	for i, le := range buckets {
+		cumulative += counts[i]
+		leStr := strconv.FormatFloat(le, 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelStringWithLE(labelNames, labelValues, leStr), cumulative); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", name, labelStringWithLE(labelNames, labelValues, "+Inf"), count); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s_sum%s %s\n", name, labelString(labelNames, labelValues), formatFloat(sum)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s_count%s %d\n", name, labelString(labelNames, labelValues), count); err != nil {
+		return err
+	}
+	return nil
+}
+
+// labelString renders names/values as a Prometheus label set, e.g.
+// `{method="GET",path="/users"}`, or "" if there are no labels.
+func labelString(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = fmt.Sprintf("%s=%q", n, values[i])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// labelStringWithLE is labelString with an additional le="..." label
+// appended, for histogram bucket lines.
+func labelStringWithLE(names, values []string, le string) string {
+	allNames := append(append([]string{}, names...), "le")
+	allValues := append(append([]string{}, values...), le)
+	return labelString(allNames, allValues)
+}
+
+// formatFloat renders a float64 the way Prometheus text format expects:
+// the shortest representation that round-trips.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// MetricsState classifies recent request latency against the thresholds
+// configured on ServerConfig, for lightweight health dashboards.
+type MetricsState string
+
+const (
+	MetricsStateOK       MetricsState = "ok"
+	MetricsStateWarning  MetricsState = "warning"
+	MetricsStateCritical MetricsState = "critical"
+)
+
+func classifyDuration(d time.Duration, cfg ServerConfig) MetricsState {
+	switch {
+	case cfg.CriticalLatency > 0 && d >= cfg.CriticalLatency:
+		return MetricsStateCritical
+	case cfg.WarningLatency > 0 && d >= cfg.WarningLatency:
+		return MetricsStateWarning
+	default:
+		return MetricsStateOK
+	}
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code
+// written by downstream handlers, which net/http does not otherwise expose.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// MetricsMiddleware records http_requests_total, http_request_duration_seconds,
+// and http_in_flight_requests for every request that passes through it, and
+// logs a warning when latency crosses the configured thresholds.
+func MetricsMiddleware(metrics MetricsRegistry, cfg ServerConfig, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inFlight := metrics.HTTPInFlightRequests()
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+
+			next.ServeHTTP(rec, r)
+
+			// r.Pattern (the matched mux pattern, e.g. "/users/{id}") is
+			// used as the label instead of r.URL.Path: the raw path has
+			// unbounded cardinality (one timeseries per user ID or hook
+			// path), while the pattern is fixed by the route table.
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			duration := time.Since(start)
+			metrics.HTTPRequestDuration(r.Method, route).Observe(duration.Seconds())
+			metrics.HTTPRequestsTotal(r.Method, route, rec.status).Inc()
+
+			if state := classifyDuration(duration, cfg); state != MetricsStateOK {
+				logger.Warn("request latency threshold exceeded",
+					slog.String("path", r.URL.Path),
+					slog.Duration("duration", duration),
+					slog.String("state", string(state)),
+				)
+			}
+		})
+	}
+}
+
+// metricsHandler serves GET /metrics by delegating to the registry's
+// exposition format (e.g. Prometheus text format for a real backend).
+func metricsHandler(metrics MetricsRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := metrics.Render(w); err != nil {
+			http.Error(w, "failed to render metrics", http.StatusInternalServerError)
+		}
+	}
+}
+
 // =============================================================================
 // Router Setup
 // =============================================================================
 
-func NewRouter(userHandler *UserHandler, logger *slog.Logger) http.Handler {
+func NewRouter(userHandler *UserHandler, hookHandler *HookHandler, logger *slog.Logger, metrics MetricsRegistry, cfg ServerConfig, limiter *RateLimiter, ready func() bool) http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check
@@ -264,13 +1004,35 @@ func NewRouter(userHandler *UserHandler, logger *slog.Logger) http.Handler {
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	// Readiness probe: 503 once the Supervisor has started shutting down,
+	// so a load balancer stops routing here before requests start failing.
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		if ready != nil && !ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"not ready"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ready"}`))
+	})
+
+	mux.HandleFunc("GET /metrics", metricsHandler(metrics))
+
 	// User routes (Go 1.22+ routing)
 	mux.HandleFunc("GET /users/{id}", userHandler.GetUser)
 	mux.HandleFunc("POST /users", userHandler.CreateUser)
 
+	// Hook routes, backed by the script-job worker pool
+	mux.HandleFunc("POST /hooks/{path...}", hookHandler.SubmitHook)
+	mux.HandleFunc("GET /hooks/jobs/{id}", hookHandler.JobStatus)
+
 	// Apply middleware
 	var handler http.Handler = mux
 	handler = LoggingMiddleware(logger)(handler)
+	if limiter != nil {
+		handler = RateLimitMiddleware(limiter, HeaderKeyFunc("X-API-Key"))(handler)
+	}
+	handler = MetricsMiddleware(metrics, cfg, logger)(handler)
 	handler = RecoveryMiddleware(logger)(handler)
 
 	return handler
@@ -285,14 +1047,21 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	// WarningLatency and CriticalLatency classify request duration for
+	// MetricsMiddleware's state logging. Zero disables that tier.
+	WarningLatency  time.Duration
+	CriticalLatency time.Duration
 }
 
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
-		Addr:         ":8080",
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Addr:            ":8080",
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		WarningLatency:  500 * time.Millisecond,
+		CriticalLatency: 2 * time.Second,
 	}
 }
 
@@ -321,34 +1090,49 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Run the worker-pool.go concurrency demos once at startup, so
+	// `go run .` exercises every pattern in this package, not just the
+	// HTTP server.
+	demoCtx, cancelDemos := context.WithTimeout(context.Background(), 30*time.Second)
+	runWorkerPoolDemos(demoCtx, logger)
+	cancelDemos()
+
 	// Initialize dependencies
 	repo := NewInMemoryUserRepository()
 	userService := NewUserService(repo, logger)
 	userHandler := NewUserHandler(userService, logger)
 
+	metrics := NewInMemoryMetricsRegistry()
+
+	hookPool := NewWorkerPool(4, 16, logger, metrics)
+	hookPool.SetScriptRunner(NewScriptRunner("/etc/myapp", 30*time.Second, 1<<20, "/var/log/myapp/hooks", logger))
+	hookHandler := NewHookHandler(hookPool, logger)
+
+	limiter := NewRateLimiter(10, 20, 10*time.Minute)
+
+	// Supervisor owns every component's lifecycle; build it before the
+	// router so /ready can report its readiness state.
+	supervisor := NewSupervisor(logger, 10*time.Second)
+
 	// Setup router and server
-	router := NewRouter(userHandler, logger)
 	cfg := DefaultServerConfig()
+	router := NewRouter(userHandler, hookHandler, logger, metrics, cfg, limiter, supervisor.Ready)
 	srv := NewHTTPServer(cfg, router)
 
-	// Start server in background
-	go func() {
-		logger.Info("server starting", slog.String("addr", srv.Addr))
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Error("server error", slog.Any("error", err))
-		}
-	}()
-
-	// Wait for shutdown signal
-	<-ctx.Done()
-	logger.Info("shutdown signal received")
-
-	// Graceful shutdown with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Components are stopped in the order given: the HTTP server first (so
+	// it drains in-flight requests), then the worker pool, then the rate
+	// limiter's background sweeper.
+	supervisor.AddComponents(
+		NewHTTPServerRunnable(srv),
+		NewWorkerPoolRunnable(hookPool, 10*time.Second),
+		NewBackgroundRunnable(limiter.Stop),
+		NewBackgroundRunnable(hookHandler.Stop),
+	)
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Error("shutdown error", slog.Any("error", err))
+	logger.Info("server starting", slog.String("addr", srv.Addr))
+	if err := supervisor.Run(ctx); err != nil {
+		logger.Error("server exited with error", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	logger.Info("server stopped gracefully")
@@ -0,0 +1,238 @@
+// Package main demonstrates a generic, composable pipeline of typed stages
+// This is a library file for the golang-pro skill's example package; see
+// http-service.go for the package's entry point.
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// Stage and Combinators
+// =============================================================================
+
+// Stage is a pipeline stage that consumes I values and produces O values.
+// Implementations must propagate context cancellation and close their
+// output channel exactly once.
+type Stage[I, O any] func(ctx context.Context, in <-chan I) <-chan O
+
+// StageResult wraps a stage's per-item output alongside an error, so a
+// stage can report a single item's failure without terminating the whole
+// pipeline. Named to avoid colliding with worker-pool.go's Result, which
+// represents a completed job's outcome rather than a single stage item's.
+type StageResult[O any] struct {
+	Value O
+	Err   error
+}
+
+// Pipe composes two stages into one: values flow A -> first -> B -> second -> C.
+func Pipe[A, B, C any](first Stage[A, B], second Stage[B, C]) Stage[A, C] {
+	return func(ctx context.Context, in <-chan A) <-chan C {
+		return second(ctx, first(ctx, in))
+	}
+}
+
+// Generator turns a fixed list of values into a pipeline's input channel,
+// emitting them in order and stopping early if ctx is cancelled.
+func Generator[T any](ctx context.Context, values ...T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, v := range values {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+	return out
+}
+
+// Square is an example Stage[int, int] that squares each input value.
+func Square(ctx context.Context, in <-chan int) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for n := range in {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- n * n:
+			}
+		}
+	}()
+	return out
+}
+
+// FanOut distributes a stage's input over n concurrent instances of it and
+// merges their outputs with FanIn. Item order is not preserved.
+func FanOut[I, O any](stage Stage[I, O], n int) Stage[I, O] {
+	return func(ctx context.Context, in <-chan I) <-chan O {
+		outputs := make([]<-chan O, n)
+		for i := 0; i < n; i++ {
+			outputs[i] = stage(ctx, in)
+		}
+		return FanIn(ctx, outputs...)
+	}
+}
+
+// FanIn merges multiple channels into one, closing it only once every
+// input channel has drained (or ctx is cancelled).
+func FanIn[T any](ctx context.Context, channels ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+
+	forward := func(c <-chan T) {
+		defer wg.Done()
+		for v := range c {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}
+
+	wg.Add(len(channels))
+	for _, c := range channels {
+		go forward(c)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// =============================================================================
+// Additional Stages
+// =============================================================================
+
+// Batch groups consecutive items into slices of at most size, flushing a
+// partial batch when the input channel closes.
+func Batch[T any](size int) Stage[T, []T] {
+	return func(ctx context.Context, in <-chan T) <-chan []T {
+		out := make(chan []T)
+		go func() {
+			defer close(out)
+
+			batch := make([]T, 0, size)
+			flush := func() {
+				if len(batch) == 0 {
+					return
+				}
+				select {
+				case <-ctx.Done():
+				case out <- batch:
+				}
+				batch = make([]T, 0, size)
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-in:
+					if !ok {
+						flush()
+						return
+					}
+					batch = append(batch, v)
+					if len(batch) == size {
+						flush()
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Throttle forwards at most one item per interval, pacing a fast producer
+// to match a slower downstream consumer without dropping anything.
+func Throttle[T any](interval time.Duration) Stage[T, T] {
+	return func(ctx context.Context, in <-chan T) <-chan T {
+		out := make(chan T)
+		go func() {
+			defer close(out)
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-in:
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case out <- v:
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
+
+// Retry wraps fn as a stage, retrying each item up to maxAttempts times
+// (with backoff between attempts) before giving up and forwarding the last
+// error in place of the item's value.
+func Retry[I, O any](fn func(context.Context, I) (O, error), maxAttempts int, backoff time.Duration) Stage[I, StageResult[O]] {
+	return func(ctx context.Context, in <-chan I) <-chan StageResult[O] {
+		out := make(chan StageResult[O])
+		go func() {
+			defer close(out)
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case item, ok := <-in:
+					if !ok {
+						return
+					}
+
+					var (
+						value O
+						err   error
+					)
+					for attempt := 0; attempt < maxAttempts; attempt++ {
+						value, err = fn(ctx, item)
+						if err == nil {
+							break
+						}
+						if attempt < maxAttempts-1 {
+							select {
+							case <-ctx.Done():
+								return
+							case <-time.After(backoff):
+							}
+						}
+					}
+
+					select {
+					case <-ctx.Done():
+						return
+					case out <- StageResult[O]{Value: value, Err: err}:
+					}
+				}
+			}
+		}()
+		return out
+	}
+}
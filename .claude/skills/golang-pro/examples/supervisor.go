@@ -0,0 +1,214 @@
+// Package main demonstrates a coordinated shutdown lifecycle manager
+// This is a library file for the golang-pro skill's example package; see
+// http-service.go for the package's entry point.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// Runnable
+// =============================================================================
+
+// Runnable is a component whose lifecycle a Supervisor coordinates. Start
+// runs the component until ctx is cancelled or it fails on its own,
+// blocking until it returns. Stop asks it to wind down, blocking until it
+// does or the passed shutdown context expires.
+type Runnable interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// httpServerRunnable adapts *http.Server to Runnable.
+type httpServerRunnable struct {
+	srv *http.Server
+}
+
+// NewHTTPServerRunnable wraps srv so a Supervisor can manage its lifecycle
+// alongside other components.
+func NewHTTPServerRunnable(srv *http.Server) Runnable {
+	return &httpServerRunnable{srv: srv}
+}
+
+func (h *httpServerRunnable) Start(ctx context.Context) error {
+	if err := h.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (h *httpServerRunnable) Stop(ctx context.Context) error {
+	return h.srv.Shutdown(ctx)
+}
+
+// workerPoolRunnable adapts *WorkerPool to Runnable, closing its jobs
+// channel on Stop and waiting for in-flight results to drain before the
+// grace period expires.
+type workerPoolRunnable struct {
+	pool       *WorkerPool
+	drainGrace time.Duration
+
+	runCtx    context.Context
+	runCancel context.CancelFunc
+}
+
+// NewWorkerPoolRunnable wraps pool so a Supervisor can start it and, on
+// shutdown, close it and wait up to drainGrace for in-flight jobs to finish.
+//
+// The pool is started on a context independent of the one passed to Start:
+// Supervisor.Run's ctx is cancelled the instant a shutdown signal arrives,
+// but workers check ctx.Err() before every job acquisition, so if the pool
+// shared that ctx it would stop pulling jobs immediately instead of draining
+// them during Stop's grace period. Start's ctx is only used to detect the
+// supervisor asking us to stop; the pool itself keeps running until Stop
+// explicitly cancels it.
+func NewWorkerPoolRunnable(pool *WorkerPool, drainGrace time.Duration) Runnable {
+	runCtx, runCancel := context.WithCancel(context.Background())
+	return &workerPoolRunnable{pool: pool, drainGrace: drainGrace, runCtx: runCtx, runCancel: runCancel}
+}
+
+func (w *workerPoolRunnable) Start(ctx context.Context) error {
+	w.pool.Start(w.runCtx)
+	<-ctx.Done()
+	return nil
+}
+
+func (w *workerPoolRunnable) Stop(ctx context.Context) error {
+	w.pool.Close()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), w.drainGrace)
+	defer cancel()
+	defer w.runCancel()
+
+	for {
+		select {
+		case _, ok := <-w.pool.Results():
+			if !ok {
+				return nil
+			}
+		case <-drainCtx.Done():
+			return fmt.Errorf("worker pool did not drain within %s", w.drainGrace)
+		}
+	}
+}
+
+// backgroundRunnable adapts a component with no meaningful "running" state
+// of its own (e.g. a RateLimiter's sweeper goroutine) to Runnable: it has
+// nothing to do on Start besides wait for shutdown, and calls stopFunc on Stop.
+type backgroundRunnable struct {
+	stopFunc func()
+}
+
+// NewBackgroundRunnable wraps stopFunc (e.g. (*RateLimiter).Stop) as a
+// Runnable with no independent start behavior.
+func NewBackgroundRunnable(stopFunc func()) Runnable {
+	return &backgroundRunnable{stopFunc: stopFunc}
+}
+
+func (b *backgroundRunnable) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (b *backgroundRunnable) Stop(ctx context.Context) error {
+	b.stopFunc()
+	return nil
+}
+
+// =============================================================================
+// Supervisor
+// =============================================================================
+
+// Supervisor owns the lifecycle of a set of Runnables and coordinates a
+// single shutdown sequence across them when Run's context is cancelled, or
+// as soon as any component fails on its own. Components are stopped in the
+// order they were given, so pass them in the order shutdown should happen:
+// typically the HTTP server first (so it stops accepting requests and
+// drains in-flight ones), then background workers, then anything else.
+type Supervisor struct {
+	components    []Runnable
+	logger        *slog.Logger
+	shutdownGrace time.Duration
+
+	ready atomic.Bool
+}
+
+// NewSupervisor returns a Supervisor for components, allowing shutdownGrace
+// for every component's Stop to complete once shutdown begins.
+func NewSupervisor(logger *slog.Logger, shutdownGrace time.Duration, components ...Runnable) *Supervisor {
+	return &Supervisor{
+		components:    components,
+		logger:        logger,
+		shutdownGrace: shutdownGrace,
+	}
+}
+
+// AddComponents appends components to the set Run will start, in the order
+// given. It must be called before Run.
+func (s *Supervisor) AddComponents(components ...Runnable) {
+	s.components = append(s.components, components...)
+}
+
+// Ready reports whether the supervisor is still accepting new work. It
+// flips to false the moment shutdown begins, intended for a GET /ready
+// probe so a load balancer stops routing to this instance before it
+// actually stops responding.
+func (s *Supervisor) Ready() bool {
+	return s.ready.Load()
+}
+
+// Run starts every component and blocks until ctx is cancelled or any
+// component returns an error, then runs the shutdown sequence and returns
+// the first fatal error encountered, if any.
+func (s *Supervisor) Run(ctx context.Context) error {
+	s.ready.Store(true)
+
+	errCh := make(chan error, len(s.components))
+	var wg sync.WaitGroup
+	for i, c := range s.components {
+		wg.Add(1)
+		go func(i int, c Runnable) {
+			defer wg.Done()
+			if err := c.Start(ctx); err != nil {
+				s.logger.Error("component failed", slog.Int("index", i), slog.Any("error", err))
+				errCh <- err
+			}
+		}(i, c)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		s.logger.Info("shutdown phase: signal received")
+	case runErr = <-errCh:
+		s.logger.Info("shutdown phase: component failure triggered shutdown")
+	}
+
+	s.ready.Store(false)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownGrace)
+	defer cancel()
+
+	for i, c := range s.components {
+		s.logger.Info("shutdown phase: stopping component", slog.Int("index", i))
+		if err := c.Stop(shutdownCtx); err != nil {
+			s.logger.Error("shutdown phase: component failed to stop cleanly",
+				slog.Int("index", i), slog.Any("error", err))
+			if runErr == nil {
+				runErr = err
+			}
+		}
+	}
+
+	wg.Wait()
+	s.logger.Info("shutdown phase: complete")
+	return runErr
+}
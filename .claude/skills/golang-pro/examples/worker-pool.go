@@ -1,12 +1,21 @@
 // Package main demonstrates a production-ready worker pool pattern
-// This is an example file for the golang-pro skill
+// This is a library file for the golang-pro skill's example package; see
+// http-service.go for the package's entry point.
 package main
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,10 +24,23 @@ import (
 // Job and Result Types
 // =============================================================================
 
-// Job represents a unit of work to be processed
+// JobKind distinguishes the kind of payload a Job carries, so a single
+// WorkerPool can dispatch to different execution strategies.
+type JobKind int
+
+const (
+	JobKindGeneric JobKind = iota
+	JobKindScript
+)
+
+// Job represents a unit of work to be processed. Payload is used by the
+// generic, simulated work in processJob; Script is populated instead when
+// Kind is JobKindScript.
 type Job struct {
 	ID      int
 	Payload string
+	Kind    JobKind
+	Script  *ScriptJob
 }
 
 // Result represents the outcome of processing a job
@@ -29,26 +51,421 @@ type Result struct {
 	Err      error
 }
 
+// =============================================================================
+// Script Jobs
+// =============================================================================
+
+// ErrBadScriptPath is returned when a hook path doesn't resolve to a script
+// under the configured root, e.g. because it tries to escape it with "..".
+var ErrBadScriptPath = errors.New("invalid script path")
+
+// ScriptJob describes a shell-hook invocation. Path mirrors a webhook-style
+// lookup: "foo/bar" resolves to "<root>/hooks/foo/bar.sh".
+type ScriptJob struct {
+	Path    string
+	Args    []string
+	Env     []string
+	Stdin   []byte
+	Timeout time.Duration
+}
+
+// ringBuffer caps retained output at maxSize bytes by dropping the oldest
+// content, so a runaway script can't exhaust memory.
+type ringBuffer struct {
+	mu      sync.Mutex
+	buf     []byte
+	maxSize int
+}
+
+func newRingBuffer(maxSize int) *ringBuffer {
+	return &ringBuffer{maxSize: maxSize}
+}
+
+func (b *ringBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.maxSize {
+		b.buf = b.buf[len(b.buf)-b.maxSize:]
+	}
+	return len(p), nil
+}
+
+func (b *ringBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// ScriptRunner resolves ScriptJob paths against a root directory and runs
+// the corresponding shell script, capturing bounded output and persisting a
+// copy of it to disk so it can be retrieved after the job completes.
+type ScriptRunner struct {
+	rootDir        string
+	defaultTimeout time.Duration
+	maxOutputBytes int
+	logDir         string
+	logger         *slog.Logger
+}
+
+// NewScriptRunner returns a ScriptRunner that looks up scripts under
+// rootDir/hooks and writes their output to logDir (if non-empty).
+func NewScriptRunner(rootDir string, defaultTimeout time.Duration, maxOutputBytes int, logDir string, logger *slog.Logger) *ScriptRunner {
+	return &ScriptRunner{
+		rootDir:        rootDir,
+		defaultTimeout: defaultTimeout,
+		maxOutputBytes: maxOutputBytes,
+		logDir:         logDir,
+		logger:         logger,
+	}
+}
+
+// resolve maps a webhook-style path like "foo/bar" to
+// "<rootDir>/hooks/foo/bar.sh", rejecting paths that escape the hooks root.
+func (sr *ScriptRunner) resolve(path string) (string, error) {
+	clean := filepath.Clean("/" + path)
+	if clean == "/" {
+		return "", fmt.Errorf("%w: empty hook path", ErrBadScriptPath)
+	}
+
+	hooksRoot := filepath.Join(sr.rootDir, "hooks")
+	script := filepath.Join(hooksRoot, clean+".sh")
+	if !strings.HasPrefix(script, hooksRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q escapes the hooks root", ErrBadScriptPath, path)
+	}
+	return script, nil
+}
+
+// Run executes job, streaming stdout/stderr into a bounded ring buffer,
+// enforcing job.Timeout (or the runner's default), and persisting the
+// captured output to logDir before returning.
+func (sr *ScriptRunner) Run(ctx context.Context, job ScriptJob) Result {
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = sr.defaultTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	scriptPath, err := sr.resolve(job.Path)
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	cmd := exec.CommandContext(runCtx, scriptPath, job.Args...)
+	cmd.Env = append(os.Environ(), job.Env...)
+	if len(job.Stdin) > 0 {
+		cmd.Stdin = bytes.NewReader(job.Stdin)
+	}
+
+	output := newRingBuffer(sr.maxOutputBytes)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result := Result{
+		Output:   output.String(),
+		Duration: time.Since(start),
+	}
+
+	if sr.logDir != "" {
+		if logErr := sr.persistLog(job.Path, result.Output); logErr != nil {
+			sr.logger.Error("failed to persist hook log", slog.String("path", job.Path), slog.Any("error", logErr))
+		}
+	}
+
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			result.Err = fmt.Errorf("script %q exited with code %d: %w", job.Path, exitErr.ExitCode(), runErr)
+		} else {
+			result.Err = fmt.Errorf("running script %q: %w", job.Path, runErr)
+		}
+	}
+	return result
+}
+
+func (sr *ScriptRunner) persistLog(hookPath, output string) error {
+	name := strings.ReplaceAll(hookPath, "/", "_") + fmt.Sprintf("-%d.log", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(sr.logDir, name), []byte(output), 0o644)
+}
+
+// =============================================================================
+// Job Acquisition
+// =============================================================================
+
+// ErrAcquirerClosed is returned by JobAcquirer.Acquire when no further jobs
+// will ever be available, signalling the worker to stop.
+var ErrAcquirerClosed = errors.New("job acquirer closed")
+
+// ErrNoJobsAvailable is returned by a JobAcquirer when no job is currently
+// ready to lease, but more may arrive later; callers should back off and
+// retry rather than treat this as terminal.
+var ErrNoJobsAvailable = errors.New("no jobs available")
+
+// AckFunc acknowledges successful processing of the job it was returned
+// alongside. Calling it is what makes delivery durable: until ack succeeds,
+// a JobAcquirer may redeliver the job to another worker after its lease
+// expires.
+type AckFunc func(ctx context.Context) error
+
+// JobAcquirer decouples WorkerPool from where jobs come from. Acquire leases
+// the next job to workerID and returns an AckFunc to call on success; Nack
+// reports a processing failure so the acquirer can schedule redelivery (or
+// give up and dead-letter it). This makes at-least-once delivery possible:
+// a job is only considered done once ack() succeeds, so a crashed worker's
+// leases eventually expire and the job is retried elsewhere.
+type JobAcquirer interface {
+	Acquire(ctx context.Context, workerID int) (Job, AckFunc, error)
+	Nack(ctx context.Context, job Job, cause error) error
+}
+
+// channelJobAcquirer adapts the original in-memory jobs channel to the
+// JobAcquirer interface. It preserves the pool's original at-most-once
+// behavior: ack and nack are both no-ops because a job pulled off the
+// channel was never persisted anywhere it could be redelivered from.
+type channelJobAcquirer struct {
+	jobs chan Job
+}
+
+func newChannelJobAcquirer(jobs chan Job) *channelJobAcquirer {
+	return &channelJobAcquirer{jobs: jobs}
+}
+
+func (c *channelJobAcquirer) Acquire(ctx context.Context, workerID int) (Job, AckFunc, error) {
+	select {
+	case <-ctx.Done():
+		return Job{}, nil, ctx.Err()
+	case job, ok := <-c.jobs:
+		if !ok {
+			return Job{}, nil, ErrAcquirerClosed
+		}
+		return job, func(context.Context) error { return nil }, nil
+	}
+}
+
+func (c *channelJobAcquirer) Nack(ctx context.Context, job Job, cause error) error {
+	return nil
+}
+
+// SQLJobAcquirer leases jobs from a durable `jobs` table using
+// SELECT ... FOR UPDATE SKIP LOCKED, so multiple worker processes can share
+// one queue without double-processing a row. It expects a schema along the
+// lines of:
+//
+//	CREATE TABLE jobs (
+//	    id           BIGINT PRIMARY KEY,
+//	    payload      TEXT NOT NULL,
+//	    state        TEXT NOT NULL DEFAULT 'pending', -- pending|leased|done|dead
+//	    attempts     INT NOT NULL DEFAULT 0,
+//	    locked_by    TEXT,
+//	    locked_until TIMESTAMPTZ
+//	)
+//
+// A lease is refreshed by a per-job heartbeat goroutine until ack or nack is
+// called, so a slow job isn't mistaken for an abandoned one and re-leased
+// out from under its worker.
+type SQLJobAcquirer struct {
+	db            *sql.DB
+	leaseDuration time.Duration
+	maxAttempts   int
+	backoffBase   time.Duration
+	deadLetter    func(job Job, cause error)
+	logger        *slog.Logger
+
+	heartbeatsMu sync.Mutex
+	heartbeats   map[int]context.CancelFunc
+}
+
+// NewSQLJobAcquirer returns a JobAcquirer backed by db. deadLetter, if
+// non-nil, is invoked for jobs that exhaust maxAttempts instead of being
+// rescheduled.
+func NewSQLJobAcquirer(db *sql.DB, leaseDuration time.Duration, maxAttempts int, backoffBase time.Duration, deadLetter func(job Job, cause error), logger *slog.Logger) *SQLJobAcquirer {
+	return &SQLJobAcquirer{
+		db:            db,
+		leaseDuration: leaseDuration,
+		maxAttempts:   maxAttempts,
+		backoffBase:   backoffBase,
+		deadLetter:    deadLetter,
+		logger:        logger,
+		heartbeats:    make(map[int]context.CancelFunc),
+	}
+}
+
+// stopHeartbeat cancels and forgets the heartbeat goroutine for jobID, if
+// one is running. It is called from both the ack and Nack paths so a job's
+// lease-refresh goroutine is stopped exactly once no matter how the job was
+// resolved.
+func (a *SQLJobAcquirer) stopHeartbeat(jobID int) {
+	a.heartbeatsMu.Lock()
+	defer a.heartbeatsMu.Unlock()
+	if cancel, ok := a.heartbeats[jobID]; ok {
+		cancel()
+		delete(a.heartbeats, jobID)
+	}
+}
+
+func (a *SQLJobAcquirer) Acquire(ctx context.Context, workerID int) (Job, AckFunc, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, nil, fmt.Errorf("beginning lease transaction: %w", err)
+	}
+
+	var job Job
+	var attempts int
+	row := tx.QueryRowContext(ctx, `
+		SELECT id, payload, attempts FROM jobs
+		WHERE state = 'pending' OR (state = 'leased' AND locked_until < now())
+		ORDER BY id
+		FOR UPDATE SKIP LOCKED
+		LIMIT 1`)
+	if err := row.Scan(&job.ID, &job.Payload, &attempts); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, nil, ErrNoJobsAvailable
+		}
+		return Job{}, nil, fmt.Errorf("scanning leasable job: %w", err)
+	}
+
+	lockedBy := fmt.Sprintf("worker-%d", workerID)
+	lockedUntil := time.Now().Add(a.leaseDuration)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE jobs SET state = 'leased', locked_by = $1, locked_until = $2, attempts = $3 WHERE id = $4`,
+		lockedBy, lockedUntil, attempts+1, job.ID,
+	); err != nil {
+		tx.Rollback()
+		return Job{}, nil, fmt.Errorf("leasing job %d: %w", job.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Job{}, nil, fmt.Errorf("committing lease for job %d: %w", job.ID, err)
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(context.Background())
+	a.heartbeatsMu.Lock()
+	a.heartbeats[job.ID] = stopHeartbeat
+	a.heartbeatsMu.Unlock()
+	go a.heartbeat(heartbeatCtx, job.ID, lockedBy)
+
+	ack := func(ctx context.Context) error {
+		a.stopHeartbeat(job.ID)
+		_, err := a.db.ExecContext(ctx, `UPDATE jobs SET state = 'done' WHERE id = $1`, job.ID)
+		return err
+	}
+	return job, ack, nil
+}
+
+// Nack reports that job failed processing. It either reschedules the job
+// after an exponential backoff or, once maxAttempts is exhausted, marks it
+// dead and invokes the configured dead-letter callback. Either way, the
+// job's heartbeat goroutine is stopped: it's done being processed by this
+// worker, whether or not it gets re-leased later.
+func (a *SQLJobAcquirer) Nack(ctx context.Context, job Job, cause error) error {
+	defer a.stopHeartbeat(job.ID)
+
+	var attempts int
+	if err := a.db.QueryRowContext(ctx, `SELECT attempts FROM jobs WHERE id = $1`, job.ID).Scan(&attempts); err != nil {
+		return fmt.Errorf("reading attempts for job %d: %w", job.ID, err)
+	}
+
+	if attempts >= a.maxAttempts {
+		if _, err := a.db.ExecContext(ctx, `UPDATE jobs SET state = 'dead' WHERE id = $1`, job.ID); err != nil {
+			return fmt.Errorf("dead-lettering job %d: %w", job.ID, err)
+		}
+		if a.deadLetter != nil {
+			a.deadLetter(job, cause)
+		}
+		return nil
+	}
+
+	backoff := time.Duration(math.Pow(2, float64(attempts))) * a.backoffBase
+	_, err := a.db.ExecContext(ctx,
+		`UPDATE jobs SET state = 'pending', locked_by = NULL, locked_until = $1 WHERE id = $2`,
+		time.Now().Add(backoff), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("rescheduling job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// heartbeat refreshes a job's lease until ctx is cancelled by the
+// corresponding ack/nack, so it isn't re-leased to another worker mid-flight.
+func (a *SQLJobAcquirer) heartbeat(ctx context.Context, jobID int, lockedBy string) {
+	ticker := time.NewTicker(a.leaseDuration / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := a.db.ExecContext(context.Background(),
+				`UPDATE jobs SET locked_until = $1 WHERE id = $2 AND locked_by = $3`,
+				time.Now().Add(a.leaseDuration), jobID, lockedBy,
+			)
+			if err != nil {
+				a.logger.Error("failed to refresh job lease",
+					slog.Int("job_id", jobID), slog.Any("error", err))
+			}
+		}
+	}
+}
+
 // =============================================================================
 // Worker Pool Implementation
 // =============================================================================
 
-// WorkerPool manages a pool of workers for concurrent job processing
+// WorkerPool manages a pool of workers for concurrent job processing. Jobs
+// are leased through a JobAcquirer; NewWorkerPool wires up the original
+// in-memory channel as the default acquirer, while NewWorkerPoolWithAcquirer
+// accepts a durable one such as SQLJobAcquirer.
 type WorkerPool struct {
-	numWorkers int
-	jobs       chan Job
-	results    chan Result
-	logger     *slog.Logger
-	wg         sync.WaitGroup
+	numWorkers       int
+	jobs             chan Job
+	results          chan Result
+	logger           *slog.Logger
+	metrics          MetricsRegistry
+	acquirer         JobAcquirer
+	externalAcquirer bool
+	scriptRunner     *ScriptRunner
+	wg               sync.WaitGroup
+}
+
+// SetScriptRunner wires up the runner used to execute JobKindScript jobs.
+// It's a separate call rather than a constructor parameter because most
+// pools never run script jobs and don't need one.
+func (wp *WorkerPool) SetScriptRunner(sr *ScriptRunner) {
+	wp.scriptRunner = sr
 }
 
-// NewWorkerPool creates a new worker pool with the specified number of workers
-func NewWorkerPool(numWorkers int, bufferSize int, logger *slog.Logger) *WorkerPool {
+// NewWorkerPool creates a new worker pool with the specified number of workers.
+// Pass NewNoopMetricsRegistry() for metrics if instrumentation isn't needed.
+func NewWorkerPool(numWorkers int, bufferSize int, logger *slog.Logger, metrics MetricsRegistry) *WorkerPool {
+	jobs := make(chan Job, bufferSize)
 	return &WorkerPool{
 		numWorkers: numWorkers,
-		jobs:       make(chan Job, bufferSize),
+		jobs:       jobs,
 		results:    make(chan Result, bufferSize),
 		logger:     logger,
+		metrics:    metrics,
+		acquirer:   newChannelJobAcquirer(jobs),
+	}
+}
+
+// NewWorkerPoolWithAcquirer creates a worker pool that leases jobs from a
+// JobAcquirer instead of the in-memory jobs channel, e.g. a SQLJobAcquirer
+// backed by a table so work survives process restarts. Submit and Close are
+// unavailable in this mode: jobs arrive through the acquirer, not the pool.
+func NewWorkerPoolWithAcquirer(numWorkers int, acquirer JobAcquirer, logger *slog.Logger, metrics MetricsRegistry) *WorkerPool {
+	return &WorkerPool{
+		numWorkers:       numWorkers,
+		results:          make(chan Result, numWorkers*2),
+		logger:           logger,
+		metrics:          metrics,
+		acquirer:         acquirer,
+		externalAcquirer: true,
 	}
 }
 
@@ -67,18 +484,30 @@ func (wp *WorkerPool) Start(ctx context.Context) {
 }
 
 // Submit adds a job to the pool for processing
-// Returns false if the pool is shutting down
+// Returns false if the pool is shutting down, or if it was built with an
+// external JobAcquirer (jobs must be enqueued through the acquirer instead).
 func (wp *WorkerPool) Submit(ctx context.Context, job Job) bool {
+	if wp.externalAcquirer {
+		wp.logger.Error("Submit called on a pool backed by an external JobAcquirer")
+		return false
+	}
+
 	select {
 	case <-ctx.Done():
 		return false
 	case wp.jobs <- job:
+		wp.metrics.JobsSubmittedTotal().Inc()
+		wp.metrics.WorkerPoolQueueDepth().Set(float64(len(wp.jobs)))
 		return true
 	}
 }
 
-// Close signals that no more jobs will be submitted
+// Close signals that no more jobs will be submitted. It is a no-op for
+// pools backed by an external JobAcquirer, which owns its own lifecycle.
 func (wp *WorkerPool) Close() {
+	if wp.externalAcquirer {
+		return
+	}
 	close(wp.jobs)
 }
 
@@ -87,27 +516,70 @@ func (wp *WorkerPool) Results() <-chan Result {
 	return wp.results
 }
 
-// worker processes jobs from the jobs channel
+// worker leases jobs from wp.acquirer until it closes or ctx is cancelled,
+// processing each one and then acking or nacking it.
 func (wp *WorkerPool) worker(ctx context.Context, id int) {
 	defer wp.wg.Done()
 
 	wp.logger.Info("worker started", slog.Int("worker_id", id))
 
 	for {
-		select {
-		case <-ctx.Done():
+		if ctx.Err() != nil {
 			wp.logger.Info("worker stopping due to context cancellation",
 				slog.Int("worker_id", id))
 			return
-		case job, ok := <-wp.jobs:
-			if !ok {
-				wp.logger.Info("worker stopping, jobs channel closed",
+		}
+
+		job, ack, err := wp.acquirer.Acquire(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrAcquirerClosed):
+				wp.logger.Info("worker stopping, acquirer closed", slog.Int("worker_id", id))
+				return
+			case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+				wp.logger.Info("worker stopping due to context cancellation",
 					slog.Int("worker_id", id))
 				return
+			case errors.Is(err, ErrNoJobsAvailable):
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(100 * time.Millisecond):
+				}
+				continue
+			default:
+				wp.logger.Error("failed to acquire job", slog.Int("worker_id", id), slog.Any("error", err))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(100 * time.Millisecond):
+				}
+				continue
 			}
-			result := wp.processJob(ctx, id, job)
-			wp.results <- result
 		}
+
+		if wp.jobs != nil {
+			wp.metrics.WorkerPoolQueueDepth().Set(float64(len(wp.jobs)))
+		}
+		wp.metrics.WorkerPoolActiveWorkers().Inc()
+		result := wp.processJob(ctx, id, job)
+		wp.metrics.WorkerPoolActiveWorkers().Dec()
+
+		resultState := "success"
+		if result.Err != nil {
+			resultState = "error"
+			if nackErr := wp.acquirer.Nack(ctx, job, result.Err); nackErr != nil {
+				wp.logger.Error("failed to nack job",
+					slog.Int("worker_id", id), slog.Int("job_id", job.ID), slog.Any("error", nackErr))
+			}
+		} else if ackErr := ack(ctx); ackErr != nil {
+			wp.logger.Error("failed to ack job",
+				slog.Int("worker_id", id), slog.Int("job_id", job.ID), slog.Any("error", ackErr))
+		}
+		wp.metrics.JobsCompletedTotal(resultState).Inc()
+		wp.metrics.JobDuration().Observe(result.Duration.Seconds())
+
+		wp.results <- result
 	}
 }
 
@@ -120,6 +592,15 @@ func (wp *WorkerPool) processJob(ctx context.Context, workerID int, job Job) Res
 		slog.Int("job_id", job.ID),
 	)
 
+	if job.Kind == JobKindScript {
+		if wp.scriptRunner == nil {
+			return Result{JobID: job.ID, Err: fmt.Errorf("job %d: no script runner configured", job.ID)}
+		}
+		result := wp.scriptRunner.Run(ctx, *job.Script)
+		result.JobID = job.ID
+		return result
+	}
+
 	// Simulate work with context awareness
 	select {
 	case <-ctx.Done():
@@ -149,9 +630,9 @@ type BatchProcessor struct {
 	logger *slog.Logger
 }
 
-func NewBatchProcessor(numWorkers int, logger *slog.Logger) *BatchProcessor {
+func NewBatchProcessor(numWorkers int, logger *slog.Logger, metrics MetricsRegistry) *BatchProcessor {
 	return &BatchProcessor{
-		pool:   NewWorkerPool(numWorkers, numWorkers*2, logger),
+		pool:   NewWorkerPool(numWorkers, numWorkers*2, logger, metrics),
 		logger: logger,
 	}
 }
@@ -187,85 +668,6 @@ func (bp *BatchProcessor) ProcessBatch(ctx context.Context, jobs []Job) ([]Resul
 	return results, nil
 }
 
-// =============================================================================
-// Fan-Out/Fan-In Pattern
-// =============================================================================
-
-// Pipeline stage function type
-type StageFunc func(ctx context.Context, in <-chan int) <-chan int
-
-// Generator creates a channel of integers from a slice
-func Generator(ctx context.Context, nums ...int) <-chan int {
-	out := make(chan int)
-	go func() {
-		defer close(out)
-		for _, n := range nums {
-			select {
-			case <-ctx.Done():
-				return
-			case out <- n:
-			}
-		}
-	}()
-	return out
-}
-
-// Square squares each number from the input channel
-func Square(ctx context.Context, in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		defer close(out)
-		for n := range in {
-			select {
-			case <-ctx.Done():
-				return
-			case out <- n * n:
-			}
-		}
-	}()
-	return out
-}
-
-// FanOut distributes work from input channel to multiple workers
-func FanOut(ctx context.Context, in <-chan int, numWorkers int, stage StageFunc) []<-chan int {
-	outputs := make([]<-chan int, numWorkers)
-	for i := 0; i < numWorkers; i++ {
-		outputs[i] = stage(ctx, in)
-	}
-	return outputs
-}
-
-// FanIn merges multiple channels into a single channel
-func FanIn(ctx context.Context, channels ...<-chan int) <-chan int {
-	out := make(chan int)
-	var wg sync.WaitGroup
-
-	// Start a goroutine for each input channel
-	output := func(c <-chan int) {
-		defer wg.Done()
-		for n := range c {
-			select {
-			case <-ctx.Done():
-				return
-			case out <- n:
-			}
-		}
-	}
-
-	wg.Add(len(channels))
-	for _, c := range channels {
-		go output(c)
-	}
-
-	// Close output channel when all inputs are done
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-
-	return out
-}
-
 // =============================================================================
 // Rate Limited Worker
 // =============================================================================
@@ -322,41 +724,189 @@ func (rp *RateLimitedProcessor) Stop() {
 }
 
 // =============================================================================
-// Main Demonstration
+// Token-Bucket Rate Limiter
 // =============================================================================
 
-func main() {
-	// Setup logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+// bucket is a single token-bucket: tokens refill continuously at a configured
+// rate up to a configured burst, and are spent one at a time by allow.
+type bucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
 
-	// Create context with cancellation
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+func (b *bucket) allow(rate, burst float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastRefill).Seconds()*rate)
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiterShardCount sets how many independent maps+mutexes a RateLimiter
+// spreads its buckets across, to reduce contention under concurrent keys.
+const rateLimiterShardCount = 32
+
+type rateLimiterShard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// RateLimiter is a token-bucket limiter keyed by an arbitrary string, such
+// as a client IP or API key, reusable as both HTTP middleware
+// (RateLimitMiddleware) and a wrapper around WorkerPool.Submit. A background
+// sweeper evicts buckets that have been idle longer than idleTimeout so
+// long-running processes don't accumulate state for clients that went away.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	shards [rateLimiterShardCount]*rateLimiterShard
+
+	idleTimeout time.Duration
+	stop        chan struct{}
+}
+
+// NewRateLimiter creates a limiter admitting up to `rate` requests per
+// second per key, with bursts up to `burst` tokens. Call Stop to halt its
+// background sweeper when the limiter is no longer needed.
+func NewRateLimiter(rate, burst float64, idleTimeout time.Duration) *RateLimiter {
+	rl := &RateLimiter{
+		rate:        rate,
+		burst:       burst,
+		idleTimeout: idleTimeout,
+		stop:        make(chan struct{}),
+	}
+	for i := range rl.shards {
+		rl.shards[i] = &rateLimiterShard{buckets: make(map[string]*bucket)}
+	}
+	go rl.sweep()
+	return rl
+}
+
+func (rl *RateLimiter) shardFor(key string) *rateLimiterShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return rl.shards[h.Sum32()%rateLimiterShardCount]
+}
+
+// Allow reports whether a request for key is admitted, consuming a token
+// from its bucket if so.
+func (rl *RateLimiter) Allow(key string) bool {
+	shard := rl.shardFor(key)
+
+	shard.mu.Lock()
+	b, ok := shard.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, lastRefill: time.Now()}
+		shard.buckets[key] = b
+	}
+	shard.mu.Unlock()
 
-	// Demo 1: Basic Worker Pool
+	return b.allow(rl.rate, rl.burst)
+}
+
+// Remaining reports key's approximate token count, for surfacing via
+// headers like X-RateLimit-Remaining.
+func (rl *RateLimiter) Remaining(key string) float64 {
+	shard := rl.shardFor(key)
+
+	shard.mu.Lock()
+	b, ok := shard.buckets[key]
+	shard.mu.Unlock()
+	if !ok {
+		return rl.burst
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// SubmitLimited wraps pool.Submit with rate limiting keyed by key, rejecting
+// (without occupying a worker slot) jobs whose key has no tokens left.
+func (rl *RateLimiter) SubmitLimited(ctx context.Context, pool *WorkerPool, key string, job Job) bool {
+	if !rl.Allow(key) {
+		return false
+	}
+	return pool.Submit(ctx, job)
+}
+
+// sweep periodically evicts buckets idle for longer than idleTimeout.
+func (rl *RateLimiter) sweep() {
+	ticker := time.NewTicker(rl.idleTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-rl.idleTimeout)
+			for _, shard := range rl.shards {
+				shard.mu.Lock()
+				for key, b := range shard.buckets {
+					b.mu.Lock()
+					idle := b.lastRefill.Before(cutoff)
+					b.mu.Unlock()
+					if idle {
+						delete(shard.buckets, key)
+					}
+				}
+				shard.mu.Unlock()
+			}
+		}
+	}
+}
+
+// Stop halts the background sweeper goroutine.
+func (rl *RateLimiter) Stop() {
+	close(rl.stop)
+}
+
+// =============================================================================
+// Demonstrations
+// =============================================================================
+//
+// worker-pool.go, pipeline.go, and supervisor.go are library files: the
+// package's single entry point is main() in http-service.go, which wires
+// the HTTP server, worker pool, and supervisor together. The demo* helpers
+// below show each piece of this file in isolation; call them from your own
+// main (or a _test.go file) to run them standalone.
+
+func runWorkerPoolDemos(ctx context.Context, logger *slog.Logger) {
 	logger.Info("=== Demo 1: Basic Worker Pool ===")
 	demoWorkerPool(ctx, logger)
 
-	// Demo 2: Batch Processor
 	logger.Info("=== Demo 2: Batch Processor ===")
 	demoBatchProcessor(ctx, logger)
 
-	// Demo 3: Fan-Out/Fan-In Pipeline
 	logger.Info("=== Demo 3: Fan-Out/Fan-In Pipeline ===")
 	demoPipeline(ctx, logger)
-
-	logger.Info("All demos completed")
 }
 
 func demoWorkerPool(ctx context.Context, logger *slog.Logger) {
-	pool := NewWorkerPool(3, 10, logger)
+	pool := NewWorkerPool(3, 10, logger, NewNoopMetricsRegistry())
 	pool.Start(ctx)
 
-	// Submit jobs
+	// Submit jobs through a RateLimiter, keyed as if by client, to
+	// demonstrate SubmitLimited alongside the HTTP middleware use of the
+	// same limiter.
+	limiter := NewRateLimiter(10, 20, 10*time.Minute)
+	defer limiter.Stop()
+
 	for i := 0; i < 5; i++ {
-		pool.Submit(ctx, Job{ID: i, Payload: fmt.Sprintf("task-%d", i)})
+		job := Job{ID: i, Payload: fmt.Sprintf("task-%d", i)}
+		if !limiter.SubmitLimited(ctx, pool, "demo-client", job) {
+			logger.Warn("job rejected by rate limiter", slog.Int("job_id", i))
+		}
 	}
 	pool.Close()
 
@@ -371,7 +921,7 @@ func demoWorkerPool(ctx context.Context, logger *slog.Logger) {
 }
 
 func demoBatchProcessor(ctx context.Context, logger *slog.Logger) {
-	processor := NewBatchProcessor(4, logger)
+	processor := NewBatchProcessor(4, logger, NewNoopMetricsRegistry())
 
 	jobs := make([]Job, 10)
 	for i := range jobs {
@@ -388,14 +938,11 @@ func demoBatchProcessor(ctx context.Context, logger *slog.Logger) {
 }
 
 func demoPipeline(ctx context.Context, logger *slog.Logger) {
-	// Create pipeline: generate -> fan-out to square workers -> fan-in results
+	// Create pipeline: generate -> fan-out to 3 square workers -> fan-in results
 	nums := Generator(ctx, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
 
-	// Fan-out to 3 square workers (note: each worker will get some of the input)
-	squareChans := FanOut(ctx, nums, 3, Square)
-
-	// Fan-in results
-	results := FanIn(ctx, squareChans...)
+	square := FanOut(Square, 3)
+	results := square(ctx, nums)
 
 	// Collect and print results
 	var sum int